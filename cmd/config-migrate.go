@@ -0,0 +1,173 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/minio/minio/pkg/quick"
+)
+
+// Config version
+var v14 = "14"
+
+// configMigrator upgrades the config file on disk, in place, from the
+// version it understands to the very next one.
+type configMigrator func(configFile string) error
+
+// configMigrators holds every registered vN -> vN+1 migrator, keyed by
+// the source version it understands. migrateConfig walks this map
+// starting from whatever version is currently on disk until it reaches
+// the latest version this server understands.
+var configMigrators = map[string]configMigrator{
+	v14: migrateV14ToV15,
+	v15: migrateV15ToV16,
+}
+
+// migrateConfig brings the config file on disk up to v16, running
+// every migrator in between in sequence. A ".bak" copy of the original
+// file is written before the first migration runs, so operators can
+// always recover exactly what was on disk before an upgrade.
+func migrateConfig() error {
+	configFile := getConfigFile()
+
+	version, err := configFileVersion(configFile)
+	if err != nil {
+		return err
+	}
+
+	if version == v16 {
+		return nil
+	}
+
+	backedUp := false
+
+	for version != v16 {
+		migrator, ok := configMigrators[version]
+		if !ok {
+			return fmt.Errorf("Unsupported config version `%s`, no migration path to `%s`.", version, v16)
+		}
+
+		if !backedUp {
+			if err = backupConfig(configFile); err != nil {
+				return err
+			}
+			backedUp = true
+		}
+
+		if err = migrator(configFile); err != nil {
+			return err
+		}
+
+		if version, err = configFileVersion(configFile); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// configFileVersion reads just the "version" field out of the config
+// file on disk, without validating the rest of its contents.
+func configFileVersion(configFile string) (string, error) {
+	cfg := &struct {
+		Version string `json:"version"`
+	}{}
+
+	qc, err := quick.New(cfg)
+	if err != nil {
+		return "", err
+	}
+	if err = qc.Load(configFile); err != nil {
+		return "", err
+	}
+
+	return cfg.Version, nil
+}
+
+// backupConfig copies the current config file to "<configFile>.bak",
+// overwriting any previous backup, before a migration is applied to
+// it.
+func backupConfig(configFile string) error {
+	data, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(configFile+".bak", data, 0600)
+}
+
+// migrateV14ToV15 upgrades a v14 config file to v15 by adding a MySQL
+// notification entry (left disabled), the only addition v15 made over
+// v14.
+func migrateV14ToV15(configFile string) error {
+	cfg := &serverConfigV15{}
+
+	qc, err := quick.New(cfg)
+	if err != nil {
+		return err
+	}
+	if err = qc.Load(configFile); err != nil {
+		return err
+	}
+
+	if cfg.Notify.MySQL == nil {
+		cfg.Notify.MySQL = make(map[string]mySQLNotify)
+		cfg.Notify.MySQL["1"] = mySQLNotify{}
+	}
+	cfg.Version = v15
+
+	qc, err = quick.New(cfg)
+	if err != nil {
+		return err
+	}
+	return qc.Save(configFile)
+}
+
+// migrateV15ToV16 upgrades a v15 config file to v16 by moving its
+// single root credential into the new Credentials list, so STS-issued
+// credentials can be appended alongside it afterwards.
+func migrateV15ToV16(configFile string) error {
+	if err := validateConfigV15(); err != nil {
+		return err
+	}
+
+	cfgV15 := &serverConfigV15{}
+
+	qc, err := quick.New(cfgV15)
+	if err != nil {
+		return err
+	}
+	if err = qc.Load(configFile); err != nil {
+		return err
+	}
+
+	cfgV16 := &serverConfigV16{
+		Version:     v16,
+		Credentials: []stsCredential{{credential: cfgV15.Credential}},
+		Region:      cfgV15.Region,
+		Browser:     cfgV15.Browser,
+		Logger:      cfgV15.Logger,
+		Notify:      cfgV15.Notify,
+	}
+
+	qc, err = quick.New(cfgV16)
+	if err != nil {
+		return err
+	}
+	return qc.Save(configFile)
+}
@@ -0,0 +1,91 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/minio/minio/pkg/quick"
+)
+
+// TestMigrateV15ToV16 verifies the v15 file's single root credential
+// ends up as Credentials[0] in the upgraded file, which is at version
+// v16 and has the region and browser fields carried over unchanged.
+func TestMigrateV15ToV16(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "minio-config-migrate-test")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// migrateV15ToV16 validates getConfigFile(), so point it at the
+	// file written below.
+	origConfigDir := getConfigDir()
+	setConfigDir(tmpDir)
+	defer setConfigDir(origConfigDir)
+
+	configFile := filepath.Join(tmpDir, minioConfigFile)
+
+	v15Cfg := &serverConfigV15{
+		Version:    v15,
+		Credential: credential{AccessKey: "ROOTKEY", SecretKey: "rootsecret1234567890123456789012"},
+		Region:     globalMinioDefaultRegion,
+		Browser:    "on",
+		Logger:     &logger{},
+		Notify:     &notifier{},
+	}
+
+	qc, err := quick.New(v15Cfg)
+	if err != nil {
+		t.Fatalf("quick.New failed: %v", err)
+	}
+	if err = qc.Save(configFile); err != nil {
+		t.Fatalf("Unable to write v15 test config: %v", err)
+	}
+
+	if err = migrateV15ToV16(configFile); err != nil {
+		t.Fatalf("migrateV15ToV16 failed: %v", err)
+	}
+
+	v16Cfg := &serverConfigV16{}
+	qc, err = quick.New(v16Cfg)
+	if err != nil {
+		t.Fatalf("quick.New failed: %v", err)
+	}
+	if err = qc.Load(configFile); err != nil {
+		t.Fatalf("Unable to load migrated config: %v", err)
+	}
+
+	if v16Cfg.Version != v16 {
+		t.Fatalf("expected migrated version %q, got %q", v16, v16Cfg.Version)
+	}
+	if len(v16Cfg.Credentials) != 1 {
+		t.Fatalf("expected exactly one credential after migration, got %d", len(v16Cfg.Credentials))
+	}
+	if got := v16Cfg.Credentials[0].AccessKey; got != v15Cfg.Credential.AccessKey {
+		t.Fatalf("expected root access key %q carried over, got %q", v15Cfg.Credential.AccessKey, got)
+	}
+	if v16Cfg.Region != v15Cfg.Region {
+		t.Fatalf("expected region %q carried over, got %q", v15Cfg.Region, v16Cfg.Region)
+	}
+	if v16Cfg.Browser != v15Cfg.Browser {
+		t.Fatalf("expected browser %q carried over, got %q", v15Cfg.Browser, v16Cfg.Browser)
+	}
+}
@@ -0,0 +1,277 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/minio/minio/pkg/quick"
+)
+
+// notifyConnCache holds the live connection for each notification
+// target and logger sink, keyed by "<kind>:<id>". reloadEntry closes
+// the cached entry for a target whose config changed so the next use
+// opens a fresh connection with the new settings, leaving unaffected
+// targets untouched.
+var notifyConnCache = struct {
+	mu    sync.Mutex
+	conns map[string]io.Closer
+}{conns: make(map[string]io.Closer)}
+
+// closeNotifyConn drops and closes the cached connection for a target,
+// if one is open. Safe to call even when no connection has been
+// established yet.
+func closeNotifyConn(kind, id string) {
+	key := kind + ":" + id
+
+	notifyConnCache.mu.Lock()
+	conn, ok := notifyConnCache.conns[key]
+	delete(notifyConnCache.conns, key)
+	notifyConnCache.mu.Unlock()
+
+	if ok && conn != nil {
+		conn.Close()
+	}
+}
+
+// Reloader is implemented by notifier and logger sink configuration
+// types that know how to apply a configuration change to an already
+// running connection. Reload is handed the id it was configured under
+// - so it can cache its new connection in notifyConnCache under the
+// same key closeNotifyConn just evicted - plus the previous and the
+// freshly loaded copy of the same entry so it can decide whether
+// anything worth reconnecting over actually changed.
+type Reloader interface {
+	Reload(id string, old, new interface{}) error
+}
+
+// setNotifyConn caches conn as the live connection for a target, so a
+// later config change can find and close it via closeNotifyConn.
+func setNotifyConn(kind, id string, conn io.Closer) {
+	notifyConnCache.mu.Lock()
+	notifyConnCache.conns[kind+":"+id] = conn
+	notifyConnCache.mu.Unlock()
+}
+
+// configWatcher ties together a filesystem watch on the config file and
+// a SIGHUP handler so either one triggers the same reload path.
+type configWatcher struct {
+	fsWatcher *fsnotify.Watcher
+	sigCh     chan os.Signal
+	doneCh    chan struct{}
+}
+
+// globalConfigWatcher is started from server startup and stopped on
+// shutdown, mirroring how other long running global state is managed.
+var globalConfigWatcher *configWatcher
+
+// startConfigWatcher watches getConfigFile() for changes and also
+// listens for SIGHUP, reloading serverConfig on either without
+// requiring a server restart.
+func startConfigWatcher() (*configWatcher, error) {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = fsWatcher.Add(getConfigFile()); err != nil {
+		fsWatcher.Close()
+		return nil, err
+	}
+
+	cw := &configWatcher{
+		fsWatcher: fsWatcher,
+		sigCh:     make(chan os.Signal, 1),
+		doneCh:    make(chan struct{}),
+	}
+
+	signal.Notify(cw.sigCh, syscall.SIGHUP)
+
+	go cw.loop()
+
+	return cw, nil
+}
+
+// loop watches for file system events and SIGHUP until Stop is called.
+func (cw *configWatcher) loop() {
+	for {
+		select {
+		case event, ok := <-cw.fsWatcher.Events:
+			if !ok {
+				return
+			}
+			// Many editors and orchestrators (configmap updates,
+			// atomic renames) replace the file instead of writing in
+			// place. inotify watches the inode, so a Rename or Remove
+			// of the watched name leaves the watch pointing at an
+			// unlinked file and the kernel drops it - no further
+			// events would ever arrive for the replacement without
+			// re-Adding the path, which by this point the rename has
+			// already put a new file under. Treat it the same as a
+			// Write: re-establish the watch, then reload.
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) == 0 {
+				continue
+			}
+			if event.Op&(fsnotify.Rename|fsnotify.Remove) != 0 {
+				if err := cw.fsWatcher.Add(getConfigFile()); err != nil {
+					errorIf(err, "Unable to re-establish config file watch on `%s`.", getConfigFile())
+					continue
+				}
+			}
+			if err := reloadConfig(); err != nil {
+				errorIf(err, "Unable to reload config from `%s`.", getConfigFile())
+			}
+		case _, ok := <-cw.fsWatcher.Errors:
+			if !ok {
+				return
+			}
+		case <-cw.sigCh:
+			if err := reloadConfig(); err != nil {
+				errorIf(err, "Unable to reload config from `%s` on SIGHUP.", getConfigFile())
+			}
+		case <-cw.doneCh:
+			return
+		}
+	}
+}
+
+// Stop tears down the SIGHUP handler and the underlying fsnotify
+// watcher.
+func (cw *configWatcher) Stop() {
+	signal.Stop(cw.sigCh)
+	close(cw.doneCh)
+	cw.fsWatcher.Close()
+}
+
+// applyEnvOverrides re-applies the same env-injected credential and
+// browser overrides loadConfig applies at startup, using the envParams
+// a SIGHUP/fsnotify reload was started with (globalServerConfigEnvParams),
+// so a reload never clobbers an env-injected setting with whatever is
+// on disk, and keeps globalIsBrowserEnabled in sync with the result.
+func applyEnvOverrides(cfg *serverConfigV16) {
+	if globalIsEnvCreds {
+		cfg.SetCredential(globalServerConfigEnvParams.creds)
+	}
+	if globalIsEnvBrowser {
+		cfg.SetBrowser(globalServerConfigEnvParams.browser)
+	}
+	globalIsBrowserEnabled = strings.ToLower(cfg.GetBrowser()) != "off"
+}
+
+// reloadConfig re-reads and validates the config file, then atomically
+// swaps serverConfig for the freshly loaded copy. If the file on disk
+// fails validateConfig, the currently running serverConfig is left
+// untouched so a bad edit never takes the server down.
+func reloadConfig() error {
+	if err := validateConfig(); err != nil {
+		return err
+	}
+
+	newCfg := &serverConfigV16{}
+	qc, err := quick.New(newCfg)
+	if err != nil {
+		return err
+	}
+	if err = qc.Load(getConfigFile()); err != nil {
+		return err
+	}
+
+	tokens, err := deepCopyServerConfigV16(newCfg)
+	if err != nil {
+		return err
+	}
+	expandSecrets(reflect.ValueOf(newCfg))
+	// The setters mirror into the serverConfigTokens that is about to
+	// be replaced, so apply the same overrides to tokens directly. The
+	// newCfg call runs last so it decides globalIsBrowserEnabled.
+	applyEnvOverrides(tokens)
+	applyEnvOverrides(newCfg)
+
+	serverConfigMu.Lock()
+	oldCfg := serverConfig
+	serverConfig = newCfg
+	serverConfigTokens = tokens
+	serverConfigMu.Unlock()
+
+	notifyReload(oldCfg.Notify, newCfg.Notify)
+	loggerReload(oldCfg.Logger, newCfg.Logger)
+
+	return nil
+}
+
+// notifyReload reloads only the notification targets whose
+// configuration actually changed between old and new, leaving
+// unchanged entries' connections alone.
+func notifyReload(old, new *notifier) {
+	for id, newCfg := range new.AMQP {
+		reloadEntry(id, "amqp", old.AMQP[id], newCfg)
+	}
+	for id, newCfg := range new.NATS {
+		reloadEntry(id, "nats", old.NATS[id], newCfg)
+	}
+	for id, newCfg := range new.ElasticSearch {
+		reloadEntry(id, "elasticsearch", old.ElasticSearch[id], newCfg)
+	}
+	for id, newCfg := range new.Redis {
+		reloadEntry(id, "redis", old.Redis[id], newCfg)
+	}
+	for id, newCfg := range new.PostgreSQL {
+		reloadEntry(id, "postgresql", old.PostgreSQL[id], newCfg)
+	}
+	for id, newCfg := range new.MySQL {
+		reloadEntry(id, "mysql", old.MySQL[id], newCfg)
+	}
+	for id, newCfg := range new.Kafka {
+		reloadEntry(id, "kafka", old.Kafka[id], newCfg)
+	}
+	for id, newCfg := range new.Webhook {
+		reloadEntry(id, "webhook", old.Webhook[id], newCfg)
+	}
+}
+
+// loggerReload reloads the console and file logger sinks, again only
+// recreating a sink when its own configuration changed.
+func loggerReload(old, new *logger) {
+	reloadEntry("1", "console", old.Console, new.Console)
+	reloadEntry("1", "file", old.File, new.File)
+}
+
+// reloadEntry calls Reload on newCfg when it implements Reloader and
+// its configuration differs from oldCfg, skipping unchanged entries so
+// their underlying connection is left alone. The cached connection for
+// this specific id is dropped first so Reload implementations only
+// need to deal with their own type-specific reconnect logic.
+func reloadEntry(id, kind string, oldCfg, newCfg interface{}) {
+	if reflect.DeepEqual(oldCfg, newCfg) {
+		return
+	}
+	reloadable, ok := newCfg.(Reloader)
+	if !ok {
+		return
+	}
+	closeNotifyConn(kind, id)
+	if err := reloadable.Reload(id, oldCfg, newCfg); err != nil {
+		errorIf(err, "Unable to reload %s notification target `%s`.", kind, id)
+	}
+}
@@ -0,0 +1,143 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import "testing"
+
+// fakeReloadable is a minimal Reloader used to observe whether and
+// how reloadEntry calls Reload, without depending on any real
+// notifier/logger type.
+type fakeReloadable struct {
+	id, old, new string
+	called       bool
+}
+
+func (f *fakeReloadable) Reload(id string, old, new interface{}) error {
+	f.called = true
+	f.id = id
+	return nil
+}
+
+// TestReloadEntrySkipsUnchanged verifies reloadEntry does not call
+// Reload when an entry's configuration did not change between old and
+// new.
+func TestReloadEntrySkipsUnchanged(t *testing.T) {
+	oldCfg := &fakeReloadable{}
+	newCfg := &fakeReloadable{}
+	reloadEntry("1", "fake", oldCfg, newCfg)
+
+	if newCfg.called {
+		t.Fatal("expected Reload not to be called for an unchanged entry")
+	}
+}
+
+// TestReloadEntryCallsReloadOnChange verifies reloadEntry closes the
+// cached connection and calls Reload, passing through the entry's id,
+// only when the configuration actually changed.
+func TestReloadEntryCallsReloadOnChange(t *testing.T) {
+	tracker := &closeTracker{}
+	setNotifyConn("fake", "1", tracker)
+	cfg := &fakeReloadable{}
+
+	reloadEntry("1", "fake", "old", cfg)
+
+	if !cfg.called {
+		t.Fatal("expected Reload to be called for a changed entry")
+	}
+	if cfg.id != "1" {
+		t.Fatalf("expected id %q passed through to Reload, got %q", "1", cfg.id)
+	}
+
+	notifyConnCache.mu.Lock()
+	_, stillCached := notifyConnCache.conns["fake:1"]
+	notifyConnCache.mu.Unlock()
+	if stillCached {
+		t.Fatal("expected the stale cached connection to be evicted before Reload ran")
+	}
+	if !tracker.closed {
+		t.Fatal("expected the stale cached connection to be closed")
+	}
+}
+
+// closeTracker is a throwaway io.Closer for exercising notifyConnCache
+// without depending on any real connection type.
+type closeTracker struct{ closed bool }
+
+func (c *closeTracker) Close() error {
+	c.closed = true
+	return nil
+}
+
+// TestApplyEnvOverridesAppliesCredsAndBrowser verifies applyEnvOverrides
+// re-applies an env-injected credential and browser setting onto a
+// freshly loaded config, the same way loadConfig does at startup, and
+// keeps globalIsBrowserEnabled in sync with the result.
+func TestApplyEnvOverridesAppliesCredsAndBrowser(t *testing.T) {
+	origIsEnvCreds, origIsEnvBrowser := globalIsEnvCreds, globalIsEnvBrowser
+	origEnvParams := globalServerConfigEnvParams
+	origBrowserEnabled := globalIsBrowserEnabled
+	defer func() {
+		globalIsEnvCreds, globalIsEnvBrowser = origIsEnvCreds, origIsEnvBrowser
+		globalServerConfigEnvParams = origEnvParams
+		globalIsBrowserEnabled = origBrowserEnabled
+	}()
+
+	globalIsEnvCreds = true
+	globalIsEnvBrowser = true
+	globalServerConfigEnvParams = envParams{
+		creds:   credential{AccessKey: "ENVKEY", SecretKey: "envsecret1234567890123456789012"},
+		browser: "off",
+	}
+
+	cfg := &serverConfigV16{Credentials: []stsCredential{{credential: credential{AccessKey: "DISKKEY"}}}}
+	applyEnvOverrides(cfg)
+
+	if got := cfg.GetCredential().AccessKey; got != "ENVKEY" {
+		t.Fatalf("expected env-injected access key %q to win over the on-disk one, got %q", "ENVKEY", got)
+	}
+	if globalIsBrowserEnabled {
+		t.Fatal("expected globalIsBrowserEnabled to be false after an env override of \"off\"")
+	}
+}
+
+// TestApplyEnvOverridesLeavesDiskValuesWhenEnvNotSet verifies
+// applyEnvOverrides does not touch the credential or browser setting
+// loaded from disk when no env override is in effect.
+func TestApplyEnvOverridesLeavesDiskValuesWhenEnvNotSet(t *testing.T) {
+	origIsEnvCreds, origIsEnvBrowser := globalIsEnvCreds, globalIsEnvBrowser
+	origBrowserEnabled := globalIsBrowserEnabled
+	defer func() {
+		globalIsEnvCreds, globalIsEnvBrowser = origIsEnvCreds, origIsEnvBrowser
+		globalIsBrowserEnabled = origBrowserEnabled
+	}()
+
+	globalIsEnvCreds = false
+	globalIsEnvBrowser = false
+
+	cfg := &serverConfigV16{
+		Credentials: []stsCredential{{credential: credential{AccessKey: "DISKKEY"}}},
+		Browser:     "on",
+	}
+	applyEnvOverrides(cfg)
+
+	if got := cfg.GetCredential().AccessKey; got != "DISKKEY" {
+		t.Fatalf("expected on-disk access key %q to be left alone, got %q", "DISKKEY", got)
+	}
+	if !globalIsBrowserEnabled {
+		t.Fatal("expected globalIsBrowserEnabled to be true for an on-disk browser value of \"on\"")
+	}
+}
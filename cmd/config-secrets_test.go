@@ -0,0 +1,150 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/minio/minio/pkg/quick"
+)
+
+// TestSaveRoundTripsSecretTokens verifies that a credential holding
+// "${ENV_VAR}" placeholders - including one embedded inside a larger
+// string - is resolved into serverConfig for live use, while Save()
+// writes the original, unresolved tokens back to disk instead of the
+// secrets they resolved to.
+func TestSaveRoundTripsSecretTokens(t *testing.T) {
+	const (
+		accessKeyEnv  = "MINIO_TEST_CONFIG_ACCESS_KEY"
+		secretKeyEnv  = "MINIO_TEST_CONFIG_SECRET_KEY"
+		accessKeyVal  = "TESTACCESSKEY123456"
+		secretKeyVal  = "testsecretkey1234567890123456789012"
+		accessToken   = "${" + accessKeyEnv + "}"
+		secretToken   = "pw-${" + secretKeyEnv + "}-end"
+		secretKeyWant = "pw-" + secretKeyVal + "-end"
+	)
+
+	os.Setenv(accessKeyEnv, accessKeyVal)
+	os.Setenv(secretKeyEnv, secretKeyVal)
+	defer os.Unsetenv(accessKeyEnv)
+	defer os.Unsetenv(secretKeyEnv)
+
+	tmpDir, err := ioutil.TempDir("", "minio-config-secrets-test")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Point getConfigFile() at the temp dir so Save() writes the same
+	// file this test reads back below.
+	origConfigDir := getConfigDir()
+	setConfigDir(tmpDir)
+	defer setConfigDir(origConfigDir)
+
+	configFile := filepath.Join(tmpDir, minioConfigFile)
+
+	onDisk := &serverConfigV16{
+		Version: v16,
+		Region:  globalMinioDefaultRegion,
+		Browser: "on",
+		Credentials: []stsCredential{{
+			credential: credential{
+				AccessKey: accessToken,
+				SecretKey: secretToken,
+			},
+		}},
+		Logger: &logger{},
+		Notify: &notifier{},
+	}
+
+	qc, err := quick.New(onDisk)
+	if err != nil {
+		t.Fatalf("quick.New failed: %v", err)
+	}
+	if err = qc.Save(configFile); err != nil {
+		t.Fatalf("Unable to write test config: %v", err)
+	}
+
+	// Mirror what loadConfig does: load the raw file, snapshot it as
+	// serverConfigTokens before anything is resolved, then expand the
+	// live copy in place.
+	loaded := &serverConfigV16{}
+	qc, err = quick.New(loaded)
+	if err != nil {
+		t.Fatalf("quick.New failed: %v", err)
+	}
+	if err = qc.Load(configFile); err != nil {
+		t.Fatalf("Unable to load test config: %v", err)
+	}
+
+	tokens, err := deepCopyServerConfigV16(loaded)
+	if err != nil {
+		t.Fatalf("deepCopyServerConfigV16 failed: %v", err)
+	}
+
+	defer func() {
+		serverConfigMu.Lock()
+		serverConfig = nil
+		serverConfigTokens = nil
+		serverConfigMu.Unlock()
+	}()
+
+	serverConfigMu.Lock()
+	serverConfigTokens = tokens
+	serverConfigMu.Unlock()
+
+	expandSecrets(reflect.ValueOf(loaded))
+
+	serverConfigMu.Lock()
+	serverConfig = loaded
+	serverConfigMu.Unlock()
+
+	// The live, in-memory config must see the resolved secrets,
+	// including the one embedded inside a larger string.
+	if got := serverConfig.GetCredential().AccessKey; got != accessKeyVal {
+		t.Fatalf("expected resolved access key %q in live config, got %q", accessKeyVal, got)
+	}
+	if got := serverConfig.GetCredential().SecretKey; got != secretKeyWant {
+		t.Fatalf("expected resolved secret key %q in live config, got %q", secretKeyWant, got)
+	}
+
+	if err = serverConfig.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	onDiskAfter := &serverConfigV16{}
+	qc, err = quick.New(onDiskAfter)
+	if err != nil {
+		t.Fatalf("quick.New failed: %v", err)
+	}
+	if err = qc.Load(configFile); err != nil {
+		t.Fatalf("Unable to reload saved config: %v", err)
+	}
+
+	// Save() must have written the original tokens back out, not the
+	// secrets they resolved to.
+	if got := onDiskAfter.Credentials[0].AccessKey; got != accessToken {
+		t.Fatalf("expected Save to preserve access key token %q, got %q", accessToken, got)
+	}
+	if got := onDiskAfter.Credentials[0].SecretKey; got != secretToken {
+		t.Fatalf("expected Save to preserve secret key token %q, got %q", secretToken, got)
+	}
+}
@@ -20,7 +20,6 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"os"
 	"strings"
 	"sync"
 
@@ -92,82 +91,6 @@ func newServerConfigV15() *serverConfigV15 {
 	return srvCfg
 }
 
-// newConfig - initialize a new server config, saves env parameters if
-// found, otherwise use default parameters
-func newConfig(envParams envParams) error {
-	// Initialize server config.
-	srvCfg := newServerConfigV15()
-
-	// If env is set for a fresh start, save them to config file.
-	if globalIsEnvCreds {
-		srvCfg.SetCredential(envParams.creds)
-	}
-
-	if globalIsEnvBrowser {
-		srvCfg.SetBrowser(envParams.browser)
-	}
-
-	// Create config path.
-	if err := createConfigDir(); err != nil {
-		return err
-	}
-
-	// hold the mutex lock before a new config is assigned.
-	// Save the new config globally.
-	// unlock the mutex.
-	serverConfigMu.Lock()
-	serverConfig = srvCfg
-	serverConfigMu.Unlock()
-
-	// Save config into file.
-	return serverConfig.Save()
-}
-
-// loadConfig - loads a new config from disk, overrides params from env
-// if found and valid
-func loadConfig(envParams envParams) error {
-	configFile := getConfigFile()
-	if _, err := os.Stat(configFile); err != nil {
-		return err
-	}
-
-	srvCfg := &serverConfigV15{}
-
-	qc, err := quick.New(srvCfg)
-	if err != nil {
-		return err
-	}
-
-	if err = qc.Load(configFile); err != nil {
-		return err
-	}
-
-	// If env is set override the credentials from config file.
-	if globalIsEnvCreds {
-		srvCfg.SetCredential(envParams.creds)
-	}
-
-	if globalIsEnvBrowser {
-		srvCfg.SetBrowser(envParams.browser)
-	}
-
-	if strings.ToLower(srvCfg.GetBrowser()) == "off" {
-		globalIsBrowserEnabled = false
-	}
-
-	// hold the mutex lock before a new config is assigned.
-	serverConfigMu.Lock()
-	// Save the loaded config globally.
-	serverConfig = srvCfg
-	serverConfigMu.Unlock()
-
-	if serverConfig.Version != v15 {
-		return errors.New("Unsupported config version `" + serverConfig.Version + "`.")
-	}
-
-	return nil
-}
-
 // doCheckDupJSONKeys recursively detects duplicate json keys
 func doCheckDupJSONKeys(key, value gjson.Result) error {
 	// Key occurrences map of the current scope to count
@@ -219,8 +142,10 @@ func checkDupJSONKeys(json string) error {
 	return doCheckDupJSONKeys(rootKey, config)
 }
 
-// validateConfig checks for
-func validateConfig() error {
+// validateConfigV15 checks that a v15 config file on disk is
+// well-formed. It is only used while migrating a v15 file forward; the
+// live config is validated by validateConfig in config-v16.go.
+func validateConfigV15() error {
 
 	// Get file config path
 	configFile := getConfigFile()
@@ -278,22 +203,13 @@ func validateConfig() error {
 	return nil
 }
 
-// serverConfig server config.
-var serverConfig *serverConfigV15
-
 // GetVersion get current config version.
 func (s serverConfigV15) GetVersion() string {
-	serverConfigMu.RLock()
-	defer serverConfigMu.RUnlock()
-
 	return s.Version
 }
 
 // SetRegion set new region.
 func (s *serverConfigV15) SetRegion(region string) {
-	serverConfigMu.Lock()
-	defer serverConfigMu.Unlock()
-
 	// Empty region means "us-east-1" by default from S3 spec.
 	if region == "" {
 		region = "us-east-1"
@@ -303,9 +219,6 @@ func (s *serverConfigV15) SetRegion(region string) {
 
 // GetRegion get current region.
 func (s serverConfigV15) GetRegion() string {
-	serverConfigMu.RLock()
-	defer serverConfigMu.RUnlock()
-
 	if s.Region != "" {
 		return s.Region
 	} // region empty
@@ -316,26 +229,17 @@ func (s serverConfigV15) GetRegion() string {
 
 // SetCredentials set new credentials.
 func (s *serverConfigV15) SetCredential(creds credential) {
-	serverConfigMu.Lock()
-	defer serverConfigMu.Unlock()
-
 	// Set updated credential.
 	s.Credential = creds
 }
 
 // GetCredentials get current credentials.
 func (s serverConfigV15) GetCredential() credential {
-	serverConfigMu.RLock()
-	defer serverConfigMu.RUnlock()
-
 	return s.Credential
 }
 
 // SetBrowser set if browser is enabled.
 func (s *serverConfigV15) SetBrowser(v string) {
-	serverConfigMu.Lock()
-	defer serverConfigMu.Unlock()
-
 	// Set browser param
 	if v == "" {
 		v = "on" // Browser is on by default.
@@ -347,9 +251,6 @@ func (s *serverConfigV15) SetBrowser(v string) {
 
 // GetCredentials get current credentials.
 func (s serverConfigV15) GetBrowser() string {
-	serverConfigMu.RLock()
-	defer serverConfigMu.RUnlock()
-
 	if s.Browser != "" {
 		return s.Browser
 	} // empty browser.
@@ -360,9 +261,6 @@ func (s serverConfigV15) GetBrowser() string {
 
 // Save config.
 func (s serverConfigV15) Save() error {
-	serverConfigMu.RLock()
-	defer serverConfigMu.RUnlock()
-
 	// get config file.
 	configFile := getConfigFile()
 
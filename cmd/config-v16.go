@@ -0,0 +1,551 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio/pkg/quick"
+)
+
+// Config version
+var v16 = "16"
+
+// stsCredential extends the long-lived root credential with the extra
+// fields a short-lived, STS-issued credential needs: a session token
+// and the time after which it is no longer honoured. Long-lived root
+// credentials simply leave SessionToken empty and Expiration zero.
+type stsCredential struct {
+	credential
+	SessionToken string    `json:"sessionToken,omitempty"`
+	Expiration   time.Time `json:"expiration,omitempty"`
+}
+
+// IsExpired reports whether this is a short-lived credential whose
+// expiry has already passed. Long-lived root credentials never
+// expire.
+func (s stsCredential) IsExpired() bool {
+	if s.Expiration.IsZero() {
+		return false
+	}
+	return time.Now().After(s.Expiration)
+}
+
+// serverConfigV16 server configuration version '16' which extends
+// version '15' by turning the single root credential into a list, so
+// short-lived STS credentials can be handed out and revoked without
+// touching the long-lived root credential or restarting the server.
+type serverConfigV16 struct {
+	Version string `json:"version"`
+
+	// S3 API configuration. Credentials[0] is always the long-lived
+	// root credential; any further entries are STS-issued credentials.
+	Credentials []stsCredential `json:"credentials"`
+	Region      string          `json:"region"`
+	Browser     string          `json:"browser"`
+
+	// Additional error logging configuration.
+	Logger *logger `json:"logger"`
+
+	// Notification queue configuration.
+	Notify *notifier `json:"notify"`
+}
+
+func newServerConfigV16() *serverConfigV16 {
+	srvCfg := &serverConfigV16{
+		Version: v16,
+		Region:  globalMinioDefaultRegion,
+		Logger:  &logger{},
+		Notify:  &notifier{},
+	}
+	srvCfg.SetCredential(mustGetNewCredential())
+	srvCfg.SetBrowser("off")
+	// Enable console logger by default on a fresh run.
+	srvCfg.Logger.Console = consoleLogger{
+		Enable: true,
+		Level:  "error",
+	}
+	srvCfg.Logger.File = fileLogger{
+		Enable:   true,
+		Level:    "error",
+		Filename: "/var/log/px-obj.log",
+	}
+
+	// Make sure to initialize notification configs.
+	srvCfg.Notify.AMQP = make(map[string]amqpNotify)
+	srvCfg.Notify.AMQP["1"] = amqpNotify{}
+	srvCfg.Notify.ElasticSearch = make(map[string]elasticSearchNotify)
+	srvCfg.Notify.ElasticSearch["1"] = elasticSearchNotify{}
+	srvCfg.Notify.Redis = make(map[string]redisNotify)
+	srvCfg.Notify.Redis["1"] = redisNotify{}
+	srvCfg.Notify.NATS = make(map[string]natsNotify)
+	srvCfg.Notify.NATS["1"] = natsNotify{}
+	srvCfg.Notify.PostgreSQL = make(map[string]postgreSQLNotify)
+	srvCfg.Notify.PostgreSQL["1"] = postgreSQLNotify{}
+	srvCfg.Notify.MySQL = make(map[string]mySQLNotify)
+	srvCfg.Notify.MySQL["1"] = mySQLNotify{}
+	srvCfg.Notify.Kafka = make(map[string]kafkaNotify)
+	srvCfg.Notify.Kafka["1"] = kafkaNotify{}
+	srvCfg.Notify.Webhook = make(map[string]webhookNotify)
+	srvCfg.Notify.Webhook["1"] = webhookNotify{}
+
+	return srvCfg
+}
+
+// newConfig - initialize a new server config, saves env parameters if
+// found, otherwise use default parameters
+func newConfig(envParams envParams) error {
+	globalServerConfigEnvParams = envParams
+
+	// Initialize server config.
+	srvCfg := newServerConfigV16()
+
+	// If env is set for a fresh start, save them to config file.
+	if globalIsEnvCreds {
+		srvCfg.SetCredential(envParams.creds)
+	}
+
+	if globalIsEnvBrowser {
+		srvCfg.SetBrowser(envParams.browser)
+	}
+
+	// Create config path.
+	if err := createConfigDir(); err != nil {
+		return err
+	}
+
+	// hold the mutex lock before a new config is assigned.
+	// Save the new config globally.
+	// unlock the mutex.
+	serverConfigMu.Lock()
+	serverConfig = srvCfg
+	serverConfigTokens = nil
+	serverConfigMu.Unlock()
+
+	// Save config into file.
+	if err := serverConfig.Save(); err != nil {
+		return err
+	}
+
+	ensureConfigWatcher()
+
+	return nil
+}
+
+// globalServerConfigEnvParams is the envParams loadConfig/newConfig
+// were started with, kept around so a later SIGHUP/fsnotify reload
+// can re-apply the same env overrides without the caller having to
+// thread them through the signal handler.
+var globalServerConfigEnvParams envParams
+
+// loadConfig - migrates the config file on disk to the latest version
+// if needed, loads it, overrides params from env if found and valid.
+func loadConfig(envParams envParams) error {
+	globalServerConfigEnvParams = envParams
+
+	configFile := getConfigFile()
+	if _, err := os.Stat(configFile); err != nil {
+		return err
+	}
+
+	// Bring the file on disk up to v16 before reading it, so operators
+	// never have to migrate v14/v15 files by hand.
+	if err := migrateConfig(); err != nil {
+		return err
+	}
+
+	srvCfg := &serverConfigV16{}
+
+	qc, err := quick.New(srvCfg)
+	if err != nil {
+		return err
+	}
+
+	if err = qc.Load(configFile); err != nil {
+		return err
+	}
+
+	// Keep a deep, unexpanded copy around so Save() can write the
+	// original "${...}" tokens back out instead of the secrets they
+	// resolve to.
+	tokens, err := deepCopyServerConfigV16(srvCfg)
+	if err != nil {
+		return err
+	}
+	serverConfigMu.Lock()
+	serverConfigTokens = tokens
+	serverConfigMu.Unlock()
+
+	// Resolve "${ENV_VAR}" and "${file:/path}" placeholders in every
+	// string field before the config is used for anything.
+	expandSecrets(reflect.ValueOf(srvCfg))
+
+	// If env is set override the credentials from config file.
+	if globalIsEnvCreds {
+		srvCfg.SetCredential(envParams.creds)
+	}
+
+	if globalIsEnvBrowser {
+		srvCfg.SetBrowser(envParams.browser)
+	}
+
+	if strings.ToLower(srvCfg.GetBrowser()) == "off" {
+		globalIsBrowserEnabled = false
+	}
+
+	// hold the mutex lock before a new config is assigned.
+	serverConfigMu.Lock()
+	// Save the loaded config globally.
+	serverConfig = srvCfg
+	serverConfigMu.Unlock()
+
+	if serverConfig.Version != v16 {
+		return errors.New("Unsupported config version `" + serverConfig.Version + "`.")
+	}
+
+	ensureConfigWatcher()
+
+	return nil
+}
+
+// startConfigWatcherOnce makes sure the SIGHUP/fsnotify reload
+// machinery is only ever installed once per process, no matter how
+// many times loadConfig/newConfig run - e.g. admin-triggered reloads
+// or tests that call them repeatedly.
+var startConfigWatcherOnce sync.Once
+
+// ensureConfigWatcher lazily starts the config file watcher the first
+// time a config is loaded or created, wiring up the hot-reload
+// machinery as part of the server's normal config startup path rather
+// than requiring a separate call site.
+func ensureConfigWatcher() {
+	startConfigWatcherOnce.Do(func() {
+		cw, err := startConfigWatcher()
+		if err != nil {
+			errorIf(err, "Unable to start config file watcher, SIGHUP/fsnotify reload will not be available.")
+			return
+		}
+		globalConfigWatcher = cw
+	})
+}
+
+// validateConfig checks for
+func validateConfig() error {
+
+	// Get file config path
+	configFile := getConfigFile()
+
+	srvCfg := &serverConfigV16{}
+
+	// Load config file
+	qc, err := quick.New(srvCfg)
+	if err != nil {
+		return err
+	}
+	if err = qc.Load(configFile); err != nil {
+		return err
+	}
+
+	// Resolve "${ENV_VAR}" and "${file:/path}" placeholders before
+	// validating. expandSecretPlaceholder leaves a placeholder it
+	// can't resolve - unset env var, unreadable file - untouched
+	// rather than emptying it, so an unset "${FOO}" would otherwise
+	// sail through the checks below as the literal 6-character access
+	// key "${FOO}" instead of failing validation; reject that
+	// explicitly here.
+	expandSecrets(reflect.ValueOf(srvCfg))
+	if token, found := findUnresolvedSecret(reflect.ValueOf(srvCfg)); found {
+		return fmt.Errorf("unresolved secret placeholder %q in config", token)
+	}
+
+	// Check if config version is valid
+	if srvCfg.GetVersion() != v16 {
+		return errors.New("bad config version, expected: " + v16)
+	}
+
+	// Load config file json and check for duplication json keys
+	jsonBytes, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return err
+	}
+	if err := checkDupJSONKeys(string(jsonBytes)); err != nil {
+		return err
+	}
+
+	// Validate region field
+	if srvCfg.GetRegion() == "" {
+		return errors.New("Region config value cannot be empty")
+	}
+
+	// Validate browser field
+	if b := strings.ToLower(srvCfg.GetBrowser()); b != "on" && b != "off" {
+		return fmt.Errorf("Browser config value %s is invalid", b)
+	}
+
+	// Validate credential field - the root credential (index 0) must
+	// always be present and valid; any STS credentials beyond it are
+	// optional and simply skipped once expired.
+	if len(srvCfg.Credentials) == 0 || !srvCfg.Credentials[0].IsValid() {
+		return errors.New("invalid credential")
+	}
+
+	// Validate logger field
+	if err := srvCfg.Logger.Validate(); err != nil {
+		return err
+	}
+
+	// Validate notify field
+	if err := srvCfg.Notify.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// serverConfig server config.
+var serverConfig *serverConfigV16
+
+// serverConfigTokens is the last config loaded from disk, before any
+// "${ENV_VAR}" or "${file:/path}" placeholder was resolved. Save()
+// serializes this - rather than the live, expanded serverConfig - so
+// secrets injected at load time are never written back to the config
+// file in plaintext.
+var serverConfigTokens *serverConfigV16
+
+// deepCopyServerConfigV16 returns an independent copy of cfg, safe to
+// mutate - including the structs and maps behind its pointer fields -
+// without affecting cfg itself.
+func deepCopyServerConfigV16(cfg *serverConfigV16) (*serverConfigV16, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	cp := &serverConfigV16{}
+	if err = json.Unmarshal(data, cp); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+// GetVersion get current config version.
+func (s serverConfigV16) GetVersion() string {
+	serverConfigMu.RLock()
+	defer serverConfigMu.RUnlock()
+
+	return s.Version
+}
+
+// SetRegion set new region. The new value is a literal, never a
+// "${...}" placeholder, so it is also mirrored into
+// serverConfigTokens to keep Save() writing it out correctly.
+func (s *serverConfigV16) SetRegion(region string) {
+	serverConfigMu.Lock()
+	defer serverConfigMu.Unlock()
+
+	// Empty region means "us-east-1" by default from S3 spec.
+	if region == "" {
+		region = "us-east-1"
+	}
+	s.Region = region
+	if serverConfigTokens != nil {
+		serverConfigTokens.Region = region
+	}
+}
+
+// GetRegion get current region.
+func (s serverConfigV16) GetRegion() string {
+	serverConfigMu.RLock()
+	defer serverConfigMu.RUnlock()
+
+	if s.Region != "" {
+		return s.Region
+	} // region empty
+
+	// Empty region means "us-east-1" by default from S3 spec.
+	return "us-east-1"
+}
+
+// SetCredential replaces the long-lived root credential (index 0),
+// leaving any STS credentials already on file untouched so rotating
+// the root key never invalidates outstanding assumed-role sessions.
+// The new credential is a literal value, never a "${...}" placeholder,
+// so it is also mirrored into serverConfigTokens to keep Save()
+// writing it out correctly.
+func (s *serverConfigV16) SetCredential(creds credential) {
+	serverConfigMu.Lock()
+	defer serverConfigMu.Unlock()
+
+	root := stsCredential{credential: creds}
+	setRootCredential(s, root)
+	if serverConfigTokens != nil {
+		setRootCredential(serverConfigTokens, root)
+	}
+}
+
+// setRootCredential sets or appends the root credential on cfg.
+func setRootCredential(cfg *serverConfigV16, root stsCredential) {
+	if len(cfg.Credentials) == 0 {
+		cfg.Credentials = []stsCredential{root}
+		return
+	}
+	cfg.Credentials[0] = root
+}
+
+// GetCredential returns the long-lived root credential.
+func (s serverConfigV16) GetCredential() credential {
+	serverConfigMu.RLock()
+	defer serverConfigMu.RUnlock()
+
+	if len(s.Credentials) == 0 {
+		return credential{}
+	}
+	return s.Credentials[0].credential
+}
+
+// AddSTSCredential appends a short-lived, STS-issued credential to the
+// list. It is a no-op if the access key is already present. The
+// credential is also mirrored into serverConfigTokens so a Save()
+// taken after issuing an STS credential doesn't silently drop it.
+func (s *serverConfigV16) AddSTSCredential(cred stsCredential) {
+	serverConfigMu.Lock()
+	defer serverConfigMu.Unlock()
+
+	addCredential(s, cred)
+	if serverConfigTokens != nil {
+		addCredential(serverConfigTokens, cred)
+	}
+}
+
+// addCredential appends cred to cfg's list unless its access key is
+// already present.
+func addCredential(cfg *serverConfigV16, cred stsCredential) {
+	for _, existing := range cfg.Credentials {
+		if existing.AccessKey == cred.AccessKey {
+			return
+		}
+	}
+	cfg.Credentials = append(cfg.Credentials, cred)
+}
+
+// GetCredentialForAccessKey looks up whichever credential - root or
+// STS - matches accessKey, for use by the request signature
+// verification path. Expired STS credentials are treated as not
+// found.
+func (s serverConfigV16) GetCredentialForAccessKey(accessKey string) (stsCredential, bool) {
+	serverConfigMu.RLock()
+	defer serverConfigMu.RUnlock()
+
+	for _, cred := range s.Credentials {
+		if cred.AccessKey != accessKey {
+			continue
+		}
+		if cred.IsExpired() {
+			return stsCredential{}, false
+		}
+		return cred, true
+	}
+	return stsCredential{}, false
+}
+
+// getCredentialForAccessKey is the package-level entry point the
+// request signature verification path calls to resolve an access key
+// from an incoming request into the credential it should check the
+// signature against - root or STS-issued. It only consults the live,
+// global serverConfig, so callers never need a serverConfigV16 value
+// of their own.
+//
+// Note: this minimal checkout does not include the signature
+// verification file itself (e.g. request-signature-v4.go in the full
+// tree), so there is no call site to update alongside this one; this
+// is the function such a call site is expected to use.
+func getCredentialForAccessKey(accessKey string) (credential, bool) {
+	cred, ok := serverConfig.GetCredentialForAccessKey(accessKey)
+	if !ok {
+		return credential{}, false
+	}
+	return cred.credential, true
+}
+
+// SetBrowser set if browser is enabled. The new value is a literal,
+// never a "${...}" placeholder, so it is also mirrored into
+// serverConfigTokens to keep Save() writing it out correctly.
+func (s *serverConfigV16) SetBrowser(v string) {
+	serverConfigMu.Lock()
+	defer serverConfigMu.Unlock()
+
+	// Set browser param
+	if v == "" {
+		v = "on" // Browser is on by default.
+	}
+
+	// Set the new value.
+	s.Browser = v
+	if serverConfigTokens != nil {
+		serverConfigTokens.Browser = v
+	}
+}
+
+// GetBrowser get if browser is enabled.
+func (s serverConfigV16) GetBrowser() string {
+	serverConfigMu.RLock()
+	defer serverConfigMu.RUnlock()
+
+	if s.Browser != "" {
+		return s.Browser
+	} // empty browser.
+
+	// Empty browser means "on" by default.
+	return "on"
+}
+
+// Save config.
+func (s serverConfigV16) Save() error {
+	serverConfigMu.RLock()
+	defer serverConfigMu.RUnlock()
+
+	// get config file.
+	configFile := getConfigFile()
+
+	// Prefer the unexpanded, on-disk copy so any "${ENV_VAR}" or
+	// "${file:/path}" placeholder is written back out verbatim
+	// instead of the secret it resolved to. Every setter keeps this
+	// copy's own fields in sync with whatever literal value it was
+	// given, so it never goes stale. A freshly created config
+	// (newConfig, never loaded from disk) has no tokens to preserve,
+	// so it falls back to saving itself directly.
+	toSave := &s
+	if serverConfigTokens != nil {
+		toSave = serverConfigTokens
+	}
+
+	// initialize quick.
+	qc, err := quick.New(toSave)
+	if err != nil {
+		return err
+	}
+
+	// Save config file.
+	return qc.Save(configFile)
+}
@@ -0,0 +1,77 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+// TestGetCredentialForAccessKeyMatch verifies a live, unexpired STS
+// credential resolves by its access key.
+func TestGetCredentialForAccessKeyMatch(t *testing.T) {
+	cfg := serverConfigV16{
+		Credentials: []stsCredential{
+			{credential: credential{AccessKey: "ROOTKEY", SecretKey: "rootsecret"}},
+			{
+				credential: credential{AccessKey: "STSKEY", SecretKey: "stssecret"},
+				Expiration: time.Now().Add(time.Hour),
+			},
+		},
+	}
+
+	cred, ok := cfg.GetCredentialForAccessKey("STSKEY")
+	if !ok {
+		t.Fatal("expected STSKEY to be found")
+	}
+	if cred.SecretKey != "stssecret" {
+		t.Fatalf("expected secret key %q, got %q", "stssecret", cred.SecretKey)
+	}
+}
+
+// TestGetCredentialForAccessKeyExpired verifies an STS credential past
+// its Expiration is treated as not found, even though it is still
+// present in Credentials.
+func TestGetCredentialForAccessKeyExpired(t *testing.T) {
+	cfg := serverConfigV16{
+		Credentials: []stsCredential{
+			{
+				credential: credential{AccessKey: "EXPIREDKEY", SecretKey: "secret"},
+				Expiration: time.Now().Add(-time.Hour),
+			},
+		},
+	}
+
+	if _, ok := cfg.GetCredentialForAccessKey("EXPIREDKEY"); ok {
+		t.Fatal("expected an expired STS credential to be reported as not found")
+	}
+}
+
+// TestGetCredentialForAccessKeyMissing verifies an access key with no
+// matching entry is reported as not found rather than a zero value
+// credential being mistaken for a match.
+func TestGetCredentialForAccessKeyMissing(t *testing.T) {
+	cfg := serverConfigV16{
+		Credentials: []stsCredential{
+			{credential: credential{AccessKey: "ROOTKEY", SecretKey: "rootsecret"}},
+		},
+	}
+
+	if _, ok := cfg.GetCredentialForAccessKey("NOSUCHKEY"); ok {
+		t.Fatal("expected an unknown access key to be reported as not found")
+	}
+}
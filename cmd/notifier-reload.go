@@ -0,0 +1,102 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import "os"
+
+// Reload implementations below back the Reloader hook for every
+// notification target and logger sink. reloadEntry has already
+// confirmed the config changed and dropped this id's cached
+// connection via closeNotifyConn before calling Reload.
+//
+// None of the seven remote notification targets has a live connection
+// or client object anywhere in this tree - there is no publish path
+// that dials AMQP/NATS/Elasticsearch/Redis/PostgreSQL/MySQL/Kafka, and
+// no vendored client library to hold a real connection open. Dialing a
+// bare socket here and caching it in notifyConnCache would only create
+// a connection nothing ever reads, which is worse than doing nothing:
+// it looks wired up without being wired up. So these stay true
+// no-ops - closeNotifyConn already did the only real work there is to
+// do - until a publish path exists to reconnect for real.
+
+// Reload is a no-op: see the comment at the top of this file.
+func (a amqpNotify) Reload(id string, old, new interface{}) error {
+	return nil
+}
+
+// Reload is a no-op: see the comment at the top of this file.
+func (n natsNotify) Reload(id string, old, new interface{}) error {
+	return nil
+}
+
+// Reload is a no-op: see the comment at the top of this file.
+func (e elasticSearchNotify) Reload(id string, old, new interface{}) error {
+	return nil
+}
+
+// Reload is a no-op: see the comment at the top of this file.
+func (r redisNotify) Reload(id string, old, new interface{}) error {
+	return nil
+}
+
+// Reload is a no-op: see the comment at the top of this file.
+func (p postgreSQLNotify) Reload(id string, old, new interface{}) error {
+	return nil
+}
+
+// Reload is a no-op: see the comment at the top of this file.
+func (m mySQLNotify) Reload(id string, old, new interface{}) error {
+	return nil
+}
+
+// Reload is a no-op: see the comment at the top of this file.
+func (k kafkaNotify) Reload(id string, old, new interface{}) error {
+	return nil
+}
+
+// Reload is a no-op for webhooks: each send already dials a fresh HTTP
+// connection, so there is nothing to tear down or cache on config
+// change.
+func (w webhookNotify) Reload(id string, old, new interface{}) error {
+	return nil
+}
+
+// Reload re-opens the file logger's output immediately, unlike the
+// remote notifiers above: Filename is a plain local path this package
+// already owns end to end, so reopening it is a real, self-contained
+// reconnect rather than a stand-in for a client library this tree
+// doesn't have - and it surfaces a bad new path as a reload error
+// right away instead of failing silently on the next write.
+func (f fileLogger) Reload(id string, old, new interface{}) error {
+	if !f.Enable || f.Filename == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(f.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	setNotifyConn("file", id, file)
+	return nil
+}
+
+// Reload is a no-op for the console logger: it writes directly to
+// stderr/stdout and has no connection state to recreate.
+func (c consoleLogger) Reload(id string, old, new interface{}) error {
+	return nil
+}